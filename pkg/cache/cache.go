@@ -0,0 +1,305 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cache provides an encrypted in-memory cache for secret store responses, so that
+// repeated GetSecret/GetBulkSecret calls for the same store/key do not need to round-trip to the
+// backing secret store component. Cached values are sealed with AES-GCM under a per-cache data
+// encryption key (DEK), and the DEK itself is wrapped by a pluggable key encryption key (KEK) so
+// that the plaintext secret value is never the only thing standing between an attacker and the
+// process memory.
+package cache
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTTL             = 60 * time.Second
+	defaultMaxRetainedDEKs = 2
+	dekTTL                 = 24 * time.Hour
+)
+
+// dek is one generation of data encryption key: the plaintext key used to seal/open cache
+// entries, the same key wrapped under the cache's KEK (kept only so it can be reported or
+// persisted by callers that need it), and when it was created.
+type dek struct {
+	key       []byte
+	wrapped   []byte
+	createdAt time.Time
+}
+
+// cacheEntry is a AES-GCM sealed secret value, tagged with the key epoch it was sealed under.
+type cacheEntry struct {
+	epoch      uint64
+	nonce      []byte
+	ciphertext []byte
+	expiresAt  time.Time
+}
+
+type storeCache struct {
+	name    string
+	ttl     time.Duration
+	kek     KEKProvider
+	maxDEKs int
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	epoch   uint64
+	deks    map[uint64]*dek
+}
+
+var (
+	cachesMu sync.RWMutex
+	caches   = map[string]*storeCache{}
+)
+
+// InitSecretStoreCaches enables (or reconfigures) the in-memory cache for a secret store.
+//
+// Supported metadata keys are "cacheEnable" (bool), "cacheTTL" (a duration string, e.g. "60s")
+// and "cacheKEKFile" (path to a local AES-256 key used to wrap the cache's data encryption key).
+// When "cacheKEKFile" is omitted, an ephemeral, process-lifetime KEK is generated instead; this is
+// fine for a single sidecar process but does not survive a restart, so production deployments
+// should configure "cacheKEKFile" or wire a KEKProvider backed by a crypto pluggable component or
+// secret store via NewSecretStoreKEKProvider.
+func InitSecretStoreCaches(storeName string, metadata map[string]string) error {
+	enabled, err := strconv.ParseBool(metadata["cacheEnable"])
+	if err != nil {
+		enabled = false
+	}
+	if !enabled {
+		cachesMu.Lock()
+		delete(caches, storeName)
+		cachesMu.Unlock()
+		return nil
+	}
+
+	ttl := defaultTTL
+	if raw, ok := metadata["cacheTTL"]; ok && raw != "" {
+		parsed, perr := time.ParseDuration(raw)
+		if perr != nil {
+			return perr
+		}
+		ttl = parsed
+	}
+
+	var kek KEKProvider
+	if path, ok := metadata["cacheKEKFile"]; ok && path != "" {
+		kek = NewLocalFileKEKProvider(path)
+	} else {
+		kek = newEphemeralKEKProvider()
+	}
+
+	sc := &storeCache{
+		name:    storeName,
+		ttl:     ttl,
+		kek:     kek,
+		maxDEKs: defaultMaxRetainedDEKs,
+		entries: map[string]cacheEntry{},
+		deks:    map[uint64]*dek{},
+	}
+	if _, err := sc.rotateLocked(context.Background()); err != nil {
+		return fmt.Errorf("failed to initialize secret cache for store %s: %w", storeName, err)
+	}
+
+	cachesMu.Lock()
+	caches[storeName] = sc
+	cachesMu.Unlock()
+	return nil
+}
+
+// Enabled reports whether caching is turned on for the given secret store.
+func Enabled(storeName string) bool {
+	cachesMu.RLock()
+	defer cachesMu.RUnlock()
+	_, ok := caches[storeName]
+	return ok
+}
+
+// Rotate generates a new data encryption key for storeName's cache, wraps it under the cache's
+// KEK, and bumps the key epoch. Entries sealed under older epochs remain readable (their DEK is
+// retained, up to defaultMaxRetainedDEKs generations and dekTTL) and are lazily re-sealed under
+// the new DEK the next time they're read.
+func Rotate(ctx context.Context, storeName string) error {
+	cachesMu.RLock()
+	sc, ok := caches[storeName]
+	cachesMu.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	_, err := sc.rotateLocked(ctx)
+	return err
+}
+
+// Get returns the cached value for storeName/key, if present, not expired, and its data
+// encryption key is still available to decrypt it.
+func Get(storeName, key string) (string, bool) {
+	cachesMu.RLock()
+	sc, ok := caches[storeName]
+	cachesMu.RUnlock()
+	if !ok {
+		return "", false
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	entry, ok := sc.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return "", false
+	}
+
+	plaintext, err := sc.openLocked(entry)
+	if err != nil {
+		decryptFailuresTotal.WithLabelValues(sc.name).Inc()
+		delete(sc.entries, key)
+		return "", false
+	}
+
+	if entry.epoch != sc.epoch {
+		// Lazily migrate the entry to the current epoch: re-seal it under the active DEK so that,
+		// as the cache is used, older DEKs become unreferenced and can be safely evicted.
+		if resealed, rerr := sc.sealLocked(plaintext); rerr == nil {
+			sc.entries[key] = resealed
+		}
+	}
+
+	return string(plaintext), true
+}
+
+// Set seals value under the cache's current data encryption key and stores it for storeName/key,
+// replacing any prior entry.
+func Set(storeName, key, value string) {
+	cachesMu.RLock()
+	sc, ok := caches[storeName]
+	cachesMu.RUnlock()
+	if !ok {
+		return
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	entry, err := sc.sealLocked([]byte(value))
+	if err != nil {
+		return
+	}
+	sc.entries[key] = entry
+}
+
+// Invalidate removes the cached value for storeName/key, if any.
+func Invalidate(storeName, key string) {
+	cachesMu.RLock()
+	sc, ok := caches[storeName]
+	cachesMu.RUnlock()
+	if !ok {
+		return
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	delete(sc.entries, key)
+}
+
+// rotateLocked generates and wraps a new DEK, bumps the epoch, and evicts DEKs beyond
+// maxDEKs generations or dekTTL old. Callers must hold sc.mu.
+func (sc *storeCache) rotateLocked(ctx context.Context) (*dek, error) {
+	plaintextKey := make([]byte, 32)
+	if _, err := rand.Read(plaintextKey); err != nil {
+		return nil, fmt.Errorf("failed to generate data encryption key: %w", err)
+	}
+	wrapped, err := sc.kek.WrapKey(ctx, plaintextKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data encryption key: %w", err)
+	}
+
+	// Round-trip the freshly wrapped key back through the KEK before relying on it: a KEK that can
+	// wrap a key but not unwrap its own output again (e.g. a misconfigured SecretStoreKEKProvider
+	// pointed at the wrong secret) must fail rotation now, not silently cache entries that nothing
+	// will ever be able to decrypt again once this epoch's in-memory plaintext is gone.
+	unwrapped, err := sc.kek.UnwrapKey(ctx, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify wrapped data encryption key: %w", err)
+	}
+
+	sc.epoch++
+	d := &dek{key: unwrapped, wrapped: wrapped, createdAt: time.Now()}
+	sc.deks[sc.epoch] = d
+	sc.evictOldDEKsLocked()
+	keyEpochTransitionsTotal.WithLabelValues(sc.name).Inc()
+	return d, nil
+}
+
+func (sc *storeCache) evictOldDEKsLocked() {
+	now := time.Now()
+	var oldestRetained uint64
+	if sc.epoch >= uint64(sc.maxDEKs) {
+		oldestRetained = sc.epoch - uint64(sc.maxDEKs) + 1
+	} else {
+		oldestRetained = 1
+	}
+	for epoch, d := range sc.deks {
+		if epoch < oldestRetained || now.Sub(d.createdAt) > dekTTL {
+			delete(sc.deks, epoch)
+		}
+	}
+}
+
+func (sc *storeCache) sealLocked(plaintext []byte) (cacheEntry, error) {
+	d, ok := sc.deks[sc.epoch]
+	if !ok {
+		return cacheEntry{}, fmt.Errorf("no active data encryption key for store %s", sc.name)
+	}
+	gcm, err := gcmFor(d)
+	if err != nil {
+		return cacheEntry{}, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return cacheEntry{}, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return cacheEntry{
+		epoch:      sc.epoch,
+		nonce:      nonce,
+		ciphertext: gcm.Seal(nil, nonce, plaintext, nil),
+		expiresAt:  time.Now().Add(sc.ttl),
+	}, nil
+}
+
+func (sc *storeCache) openLocked(entry cacheEntry) ([]byte, error) {
+	d, ok := sc.deks[entry.epoch]
+	if !ok {
+		return nil, fmt.Errorf("data encryption key for epoch %d has been evicted", entry.epoch)
+	}
+	gcm, err := gcmFor(d)
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Open(nil, entry.nonce, entry.ciphertext, nil)
+}
+
+func gcmFor(d *dek) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(d.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cleanupCache deletes storeName's cache once the test finishes, so the package-level caches map
+// doesn't leak state between tests.
+func cleanupCache(t *testing.T, storeName string) {
+	t.Cleanup(func() {
+		cachesMu.Lock()
+		delete(caches, storeName)
+		cachesMu.Unlock()
+	})
+}
+
+func TestGetMigratesEntryToCurrentEpochOnRead(t *testing.T) {
+	const storeName = "epoch-migration-store"
+	cleanupCache(t, storeName)
+
+	require.NoError(t, InitSecretStoreCaches(storeName, map[string]string{"cacheEnable": "true"}))
+	Set(storeName, "key1", "value1")
+
+	cachesMu.RLock()
+	sc := caches[storeName]
+	cachesMu.RUnlock()
+	require.NotNil(t, sc)
+
+	oldEpoch := sc.epoch
+	require.NoError(t, Rotate(context.Background(), storeName))
+	assert.Equal(t, oldEpoch+1, sc.epoch)
+
+	sc.mu.Lock()
+	entry := sc.entries["key1"]
+	sc.mu.Unlock()
+	assert.Equal(t, oldEpoch, entry.epoch, "entry should still be sealed under the pre-rotation epoch before it's read")
+
+	val, ok := Get(storeName, "key1")
+	require.True(t, ok)
+	assert.Equal(t, "value1", val)
+
+	sc.mu.Lock()
+	entry = sc.entries["key1"]
+	sc.mu.Unlock()
+	assert.Equal(t, sc.epoch, entry.epoch, "Get should have lazily re-sealed the entry under the current epoch")
+
+	// The migrated entry must still decrypt to the same value under its new epoch.
+	val, ok = Get(storeName, "key1")
+	require.True(t, ok)
+	assert.Equal(t, "value1", val)
+}
+
+func TestDEKEvictionBeyondMaxRetained(t *testing.T) {
+	const storeName = "eviction-store"
+	cleanupCache(t, storeName)
+
+	require.NoError(t, InitSecretStoreCaches(storeName, map[string]string{"cacheEnable": "true"}))
+	Set(storeName, "key1", "value1")
+
+	cachesMu.RLock()
+	sc := caches[storeName]
+	cachesMu.RUnlock()
+	require.NotNil(t, sc)
+	require.Equal(t, defaultMaxRetainedDEKs, sc.maxDEKs)
+
+	sealedEpoch := sc.epoch
+
+	ctx := context.Background()
+	for i := 0; i < sc.maxDEKs; i++ {
+		require.NoError(t, Rotate(ctx, storeName))
+	}
+
+	sc.mu.Lock()
+	_, dekStillPresent := sc.deks[sealedEpoch]
+	sc.mu.Unlock()
+	assert.False(t, dekStillPresent, "the DEK for the epoch key1 was sealed under should have been evicted")
+
+	// Reading an entry whose DEK has been evicted must fail closed, not return stale or garbage
+	// plaintext, and must clean up the now-undecryptable entry.
+	val, ok := Get(storeName, "key1")
+	assert.False(t, ok)
+	assert.Empty(t, val)
+
+	sc.mu.Lock()
+	_, entryStillPresent := sc.entries["key1"]
+	sc.mu.Unlock()
+	assert.False(t, entryStillPresent, "an entry that failed to decrypt should be removed from the cache")
+}
+
+// fakeKEKSecretStore is a minimal secretstores.SecretStore that serves a single fixed secret, for
+// exercising SecretStoreKEKProvider without depending on a real secret store component.
+type fakeKEKSecretStore struct {
+	secretName string
+	value      string
+}
+
+func (f fakeKEKSecretStore) GetSecret(req secretstores.GetSecretRequest) (secretstores.GetSecretResponse, error) {
+	if req.Name != f.secretName {
+		return secretstores.GetSecretResponse{}, errors.New("unknown secret " + req.Name)
+	}
+	return secretstores.GetSecretResponse{Data: map[string]string{f.secretName: f.value}}, nil
+}
+
+func (f fakeKEKSecretStore) BulkGetSecret(req secretstores.BulkGetSecretRequest) (secretstores.BulkGetSecretResponse, error) {
+	return secretstores.BulkGetSecretResponse{Data: map[string]map[string]string{f.secretName: {f.secretName: f.value}}}, nil
+}
+
+func (f fakeKEKSecretStore) Init(metadata secretstores.Metadata) error { return nil }
+
+func (f fakeKEKSecretStore) Close() error { return nil }
+
+func TestKEKProviderRoundTrip(t *testing.T) {
+	rawKey := make([]byte, 32)
+	_, err := rand.Read(rawKey)
+	require.NoError(t, err)
+
+	kekFile := filepath.Join(t.TempDir(), "kek")
+	require.NoError(t, os.WriteFile(kekFile, rawKey, 0o600))
+
+	tests := map[string]KEKProvider{
+		"ephemeral":    newEphemeralKEKProvider(),
+		"local file":   NewLocalFileKEKProvider(kekFile),
+		"secret store": NewSecretStoreKEKProvider(fakeKEKSecretStore{secretName: "kek", value: string(rawKey)}, "kek"),
+	}
+
+	for name, provider := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			plaintextDEK := make([]byte, 32)
+			_, err := rand.Read(plaintextDEK)
+			require.NoError(t, err)
+
+			wrapped, err := provider.WrapKey(ctx, plaintextDEK)
+			require.NoError(t, err)
+			assert.NotEqual(t, plaintextDEK, wrapped, "a wrapped DEK must not equal its own plaintext")
+
+			unwrapped, err := provider.UnwrapKey(ctx, wrapped)
+			require.NoError(t, err)
+			assert.Equal(t, plaintextDEK, unwrapped)
+		})
+	}
+}
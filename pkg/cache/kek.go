@@ -0,0 +1,181 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"os"
+
+	"github.com/dapr/components-contrib/secretstores"
+)
+
+// KEKProvider wraps and unwraps a data encryption key (DEK) with a key encryption key (KEK) that
+// it alone holds. The cache never sees the KEK directly: it hands the provider a freshly generated
+// plaintext DEK and keeps the wrapped bytes it gets back in memory, next to that DEK.
+type KEKProvider interface {
+	WrapKey(ctx context.Context, plaintextDEK []byte) ([]byte, error)
+	UnwrapKey(ctx context.Context, wrappedDEK []byte) ([]byte, error)
+}
+
+// ephemeralKEKProvider generates a random AES-256 key once, when it is created, and holds it only
+// in memory. It is the zero-configuration fallback used when no KEKProvider is configured: it
+// keeps DEKs out of the cache entries themselves, but does not survive a process restart and so
+// is not suitable beyond local development.
+type ephemeralKEKProvider struct {
+	gcm cipher.AEAD
+}
+
+func newEphemeralKEKProvider() *ephemeralKEKProvider {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		// crypto/rand.Read only fails if the OS entropy source is unusable, in which case the
+		// process cannot safely generate any key material; there is nothing a caller could do to
+		// recover from this, so failing InitSecretStoreCaches with a wrapped error isn't warranted.
+		panic(fmt.Sprintf("cache: failed to generate ephemeral KEK: %v", err))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		panic(fmt.Sprintf("cache: failed to initialize ephemeral KEK: %v", err))
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(fmt.Sprintf("cache: failed to initialize ephemeral KEK: %v", err))
+	}
+	return &ephemeralKEKProvider{gcm: gcm}
+}
+
+func (p *ephemeralKEKProvider) WrapKey(_ context.Context, plaintextDEK []byte) ([]byte, error) {
+	nonce := make([]byte, p.gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return p.gcm.Seal(nonce, nonce, plaintextDEK, nil), nil
+}
+
+func (p *ephemeralKEKProvider) UnwrapKey(_ context.Context, wrappedDEK []byte) ([]byte, error) {
+	nonceSize := p.gcm.NonceSize()
+	if len(wrappedDEK) < nonceSize {
+		return nil, fmt.Errorf("wrapped DEK is too short")
+	}
+	nonce, ciphertext := wrappedDEK[:nonceSize], wrappedDEK[nonceSize:]
+	return p.gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+// LocalFileKEKProvider reads a 32-byte AES-256 key from a file on disk and uses it to seal DEKs
+// with AES-GCM. It is meant for local development only: the KEK never leaves the process, and
+// losing the file means losing every DEK it ever wrapped.
+type LocalFileKEKProvider struct {
+	path string
+}
+
+// NewLocalFileKEKProvider returns a KEKProvider that reads its key material from path.
+func NewLocalFileKEKProvider(path string) *LocalFileKEKProvider {
+	return &LocalFileKEKProvider{path: path}
+}
+
+func (p *LocalFileKEKProvider) key() (cipher.AEAD, error) {
+	raw, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read KEK file %s: %w", p.path, err)
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid KEK in %s: %w", p.path, err)
+	}
+	return cipher.NewGCM(block)
+}
+
+func (p *LocalFileKEKProvider) WrapKey(_ context.Context, plaintextDEK []byte) ([]byte, error) {
+	gcm, err := p.key()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, plaintextDEK, nil), nil
+}
+
+func (p *LocalFileKEKProvider) UnwrapKey(_ context.Context, wrappedDEK []byte) ([]byte, error) {
+	gcm, err := p.key()
+	if err != nil {
+		return nil, err
+	}
+	nonceSize := gcm.NonceSize()
+	if len(wrappedDEK) < nonceSize {
+		return nil, fmt.Errorf("wrapped DEK is too short")
+	}
+	nonce, ciphertext := wrappedDEK[:nonceSize], wrappedDEK[nonceSize:]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+// SecretStoreKEKProvider wraps/unwraps DEKs using a key fetched from a configured Dapr secret
+// store, following the same lookup a component would use to fetch any other secret. This is the
+// recommended production provider: the KEK lives wherever the operator already trusts secrets to
+// live (a KMS-backed store, Vault, etc.), and is never written to local disk.
+type SecretStoreKEKProvider struct {
+	store      secretstores.SecretStore
+	secretName string
+}
+
+// NewSecretStoreKEKProvider returns a KEKProvider backed by secretName in store.
+func NewSecretStoreKEKProvider(store secretstores.SecretStore, secretName string) *SecretStoreKEKProvider {
+	return &SecretStoreKEKProvider{store: store, secretName: secretName}
+}
+
+func (p *SecretStoreKEKProvider) gcm(ctx context.Context) (cipher.AEAD, error) {
+	resp, err := p.store.GetSecret(secretstores.GetSecretRequest{Name: p.secretName})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch KEK secret %s: %w", p.secretName, err)
+	}
+	raw, ok := resp.Data[p.secretName]
+	if !ok {
+		return nil, fmt.Errorf("KEK secret %s has no value", p.secretName)
+	}
+	block, err := aes.NewCipher([]byte(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid KEK secret %s: %w", p.secretName, err)
+	}
+	return cipher.NewGCM(block)
+}
+
+func (p *SecretStoreKEKProvider) WrapKey(ctx context.Context, plaintextDEK []byte) ([]byte, error) {
+	gcm, err := p.gcm(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, plaintextDEK, nil), nil
+}
+
+func (p *SecretStoreKEKProvider) UnwrapKey(ctx context.Context, wrappedDEK []byte) ([]byte, error) {
+	gcm, err := p.gcm(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nonceSize := gcm.NonceSize()
+	if len(wrappedDEK) < nonceSize {
+		return nil, fmt.Errorf("wrapped DEK is too short")
+	}
+	nonce, ciphertext := wrappedDEK[:nonceSize], wrappedDEK[nonceSize:]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
@@ -0,0 +1,39 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	// decryptFailuresTotal counts cache entries that failed to decrypt, keyed by secret store name.
+	// A non-zero rate here usually means a DEK was evicted or its KEK rotated out from under it.
+	decryptFailuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "dapr",
+		Subsystem: "secret_cache",
+		Name:      "decrypt_failures_total",
+		Help:      "Number of secret cache entries that failed to decrypt.",
+	}, []string{"store"})
+
+	// keyEpochTransitionsTotal counts DEK rotations, keyed by secret store name.
+	keyEpochTransitionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "dapr",
+		Subsystem: "secret_cache",
+		Name:      "key_epoch_transitions_total",
+		Help:      "Number of data encryption key rotations performed on the secret cache.",
+	}, []string{"store"})
+)
+
+func init() {
+	prometheus.MustRegister(decryptFailuresTotal, keyEpochTransitionsTotal)
+}
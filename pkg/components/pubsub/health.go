@@ -0,0 +1,99 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"sync"
+
+	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/dapr/pkg/resiliency"
+)
+
+// pingablePubSub is implemented by pub/sub components that can report their own health. A
+// component that doesn't implement it is reported as healthy, since there is nothing to probe.
+type pingablePubSub interface {
+	Ping() error
+}
+
+// ComponentHealth is the health-check outcome for a single instantiated pub/sub component.
+type ComponentHealth struct {
+	Name    string `json:"name"`
+	Healthy bool   `json:"healthy"`
+	Error   string `json:"error,omitempty"`
+}
+
+// HealthReport is the aggregate result of Registry.HealthCheck, suitable for exposing on
+// /healthz/components.
+type HealthReport struct {
+	Components []ComponentHealth `json:"components"`
+}
+
+// HealthCheck concurrently probes every pub/sub component this registry has instantiated,
+// bounding each probe with the timeout from that component's outbound resiliency policy.
+func (p *Registry) HealthCheck(ctx context.Context) HealthReport {
+	p.instancesMu.Lock()
+	instances := make(map[string]pubsub.PubSub, len(p.instances))
+	for name, ps := range p.instances {
+		instances[name] = ps
+	}
+	p.instancesMu.Unlock()
+
+	results := make([]ComponentHealth, len(instances))
+	names := make([]string, 0, len(instances))
+	for name := range instances {
+		names = append(names, name)
+	}
+
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			results[i] = p.probe(ctx, name, instances[name])
+		}(i, name)
+	}
+	wg.Wait()
+
+	return HealthReport{Components: results}
+}
+
+func (p *Registry) probe(ctx context.Context, name string, ps pubsub.PubSub) ComponentHealth {
+	target := ps
+	if uw, ok := ps.(unwrapper); ok {
+		target = uw.Unwrap()
+	}
+
+	pingable, ok := target.(pingablePubSub)
+	if !ok {
+		return ComponentHealth{Name: name, Healthy: true}
+	}
+
+	operation := func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, pingable.Ping()
+	}
+
+	var err error
+	if p.Resiliency != nil {
+		runner := resiliency.NewRunner[struct{}](ctx, p.Resiliency.ComponentOutboundPolicy(name, resiliency.Pubsub))
+		_, err = runner(operation)
+	} else {
+		_, err = operation(ctx)
+	}
+
+	if err != nil {
+		return ComponentHealth{Name: name, Healthy: false, Error: err.Error()}
+	}
+	return ComponentHealth{Name: name, Healthy: true}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+
+	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/kit/logger"
+)
+
+// PublishFn is the shape of pubsub.PubSub.Publish, passed down a middleware chain so each
+// middleware can run logic before/after the call, or short-circuit it entirely.
+type PublishFn func(req *pubsub.PublishRequest) error
+
+// BulkPublishFn is the bulk-publish equivalent of PublishFn.
+type BulkPublishFn func(req pubsub.BulkPublishRequest) (pubsub.BulkPublishResponse, error)
+
+// PubSubMiddleware can intercept Publish, BulkPublish and the handler invoked for each Subscribe
+// callback. Each method receives the next link in the chain and returns a (possibly wrapping)
+// replacement for it; returning the argument unchanged is a no-op for that operation.
+type PubSubMiddleware interface {
+	Publish(next PublishFn) PublishFn
+	BulkPublish(next BulkPublishFn) BulkPublishFn
+	Subscribe(next pubsub.Handler) pubsub.Handler
+}
+
+// MiddlewareFactory builds a PubSubMiddleware from its component-style metadata.
+type MiddlewareFactory func(log logger.Logger, metadata map[string]string) PubSubMiddleware
+
+// publisherAware is implemented by middlewares that need to publish messages of their own (e.g.
+// dead-letter routing). The registry calls SetPublisher once, right after building the chain,
+// with a function that publishes directly through the underlying component - bypassing the chain
+// itself, so a middleware's own publishes are never re-intercepted by earlier middlewares.
+type publisherAware interface {
+	SetPublisher(publish PublishFn)
+}
+
+// wrappedPubSub decorates a pubsub.PubSub with a middleware chain. Any method not related to
+// publishing/subscribing (Init, Close, Features, ...) passes straight through to the embedded
+// pubsub.PubSub. It deliberately does not define BulkPublish: embedding pubsub.PubSub doesn't
+// promote that optional method, so a type assertion for bulkPublisher against a *wrappedPubSub
+// correctly fails exactly when the wrapped component itself doesn't support bulk publish, letting
+// the runtime's existing per-message Publish fallback kick in as it did before middleware existed.
+type wrappedPubSub struct {
+	pubsub.PubSub
+	chain []PubSubMiddleware
+}
+
+// bulkPublisher is implemented by pubsub components that support publishing a batch of messages
+// in one call (github.com/dapr/components-contrib/pubsub.BulkPublisher).
+type bulkPublisher interface {
+	BulkPublish(req pubsub.BulkPublishRequest) (pubsub.BulkPublishResponse, error)
+}
+
+// wrappedBulkPubSub is a wrappedPubSub whose underlying component also implements bulkPublisher.
+// It's only ever constructed when that's true, so a bulkPublisher type assertion against its
+// pointer type is exactly as reliable as asserting it against the unwrapped component directly.
+type wrappedBulkPubSub struct {
+	*wrappedPubSub
+	bulk bulkPublisher
+}
+
+// unwrapper is implemented by every pubsub wrapper type this package produces, so callers that
+// need the underlying component itself (e.g. Registry.probe, to check for an optional capability
+// like Ping) don't need to know which wrapper shape was used to reach it.
+type unwrapper interface {
+	Unwrap() pubsub.PubSub
+}
+
+func (w *wrappedPubSub) Unwrap() pubsub.PubSub {
+	return w.PubSub
+}
+
+func wrapWithMiddleware(ps pubsub.PubSub, chain []PubSubMiddleware) pubsub.PubSub {
+	if len(chain) == 0 {
+		return ps
+	}
+
+	w := &wrappedPubSub{PubSub: ps, chain: chain}
+	for _, mw := range chain {
+		if aware, ok := mw.(publisherAware); ok {
+			aware.SetPublisher(ps.Publish)
+		}
+	}
+
+	if bp, ok := ps.(bulkPublisher); ok {
+		return &wrappedBulkPubSub{wrappedPubSub: w, bulk: bp}
+	}
+	return w
+}
+
+func (w *wrappedPubSub) Publish(req *pubsub.PublishRequest) error {
+	next := w.PubSub.Publish
+	for i := len(w.chain) - 1; i >= 0; i-- {
+		next = w.chain[i].Publish(next)
+	}
+	return next(req)
+}
+
+func (w *wrappedPubSub) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handler pubsub.Handler) error {
+	for i := len(w.chain) - 1; i >= 0; i-- {
+		handler = w.chain[i].Subscribe(handler)
+	}
+	return w.PubSub.Subscribe(ctx, req, handler)
+}
+
+func (w *wrappedBulkPubSub) BulkPublish(req pubsub.BulkPublishRequest) (pubsub.BulkPublishResponse, error) {
+	next := w.bulk.BulkPublish
+	for i := len(w.chain) - 1; i >= 0; i-- {
+		next = w.chain[i].BulkPublish(next)
+	}
+	return next(req)
+}
@@ -0,0 +1,254 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
+
+	"github.com/dapr/components-contrib/pubsub"
+	"github.com/dapr/kit/logger"
+)
+
+const (
+	defaultRateLimitPerSecond = 100
+	defaultRateLimitBurst     = 100
+)
+
+// init registers the built-in middlewares on DefaultRegistry, under the names operators use in a
+// component's `middleware` metadata.
+func init() {
+	DefaultRegistry.RegisterMiddleware("otel", NewTracingMiddleware)
+	DefaultRegistry.RegisterMiddleware("ratelimit", NewRateLimitMiddleware)
+	DefaultRegistry.RegisterMiddleware("deadletter", NewDeadLetterMiddleware)
+	DefaultRegistry.RegisterMiddleware("cloudevents", NewCloudEventsValidationMiddleware)
+}
+
+// tracingMiddleware emits an OpenTelemetry span around every publish, bulk publish and subscribe
+// callback, tagging each with the pubsub topic involved.
+type tracingMiddleware struct {
+	tracer trace.Tracer
+}
+
+// NewTracingMiddleware returns a PubSubMiddleware that traces publish/subscribe calls with
+// OpenTelemetry.
+func NewTracingMiddleware(log logger.Logger, metadata map[string]string) PubSubMiddleware {
+	return &tracingMiddleware{tracer: otel.Tracer("github.com/dapr/dapr/pkg/components/pubsub")}
+}
+
+func (m *tracingMiddleware) Publish(next PublishFn) PublishFn {
+	return func(req *pubsub.PublishRequest) error {
+		_, span := m.tracer.Start(context.Background(), "pubsub.publish", trace.WithAttributes(attribute.String("topic", req.Topic)))
+		defer span.End()
+		err := next(req)
+		if err != nil {
+			span.RecordError(err)
+		}
+		return err
+	}
+}
+
+func (m *tracingMiddleware) BulkPublish(next BulkPublishFn) BulkPublishFn {
+	return func(req pubsub.BulkPublishRequest) (pubsub.BulkPublishResponse, error) {
+		_, span := m.tracer.Start(context.Background(), "pubsub.bulk_publish", trace.WithAttributes(attribute.String("topic", req.Topic)))
+		defer span.End()
+		resp, err := next(req)
+		if err != nil {
+			span.RecordError(err)
+		}
+		return resp, err
+	}
+}
+
+func (m *tracingMiddleware) Subscribe(next pubsub.Handler) pubsub.Handler {
+	return func(ctx context.Context, msg *pubsub.NewMessage) error {
+		ctx, span := m.tracer.Start(ctx, "pubsub.subscribe", trace.WithAttributes(attribute.String("topic", msg.Topic)))
+		defer span.End()
+		err := next(ctx, msg)
+		if err != nil {
+			span.RecordError(err)
+		}
+		return err
+	}
+}
+
+// rateLimitMiddleware enforces an independent token-bucket rate limit per topic, so a burst on
+// one topic can't starve publishes to another.
+type rateLimitMiddleware struct {
+	rps   rate.Limit
+	burst int
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+// NewRateLimitMiddleware returns a PubSubMiddleware that rate-limits Publish/BulkPublish per
+// topic. Metadata keys: "ratePerSecond" (float, default 100), "burst" (int, default 100).
+func NewRateLimitMiddleware(log logger.Logger, metadata map[string]string) PubSubMiddleware {
+	rps := float64(defaultRateLimitPerSecond)
+	if raw, ok := metadata["ratePerSecond"]; ok && raw != "" {
+		if parsed, err := strconv.ParseFloat(raw, 64); err == nil {
+			rps = parsed
+		}
+	}
+	burst := defaultRateLimitBurst
+	if raw, ok := metadata["burst"]; ok && raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil {
+			burst = parsed
+		}
+	}
+	return &rateLimitMiddleware{rps: rate.Limit(rps), burst: burst, limiters: map[string]*rate.Limiter{}}
+}
+
+func (m *rateLimitMiddleware) limiterFor(topic string) *rate.Limiter {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l, ok := m.limiters[topic]
+	if !ok {
+		l = rate.NewLimiter(m.rps, m.burst)
+		m.limiters[topic] = l
+	}
+	return l
+}
+
+func (m *rateLimitMiddleware) Publish(next PublishFn) PublishFn {
+	return func(req *pubsub.PublishRequest) error {
+		if !m.limiterFor(req.Topic).Allow() {
+			return fmt.Errorf("rate limit exceeded for topic %s", req.Topic)
+		}
+		return next(req)
+	}
+}
+
+func (m *rateLimitMiddleware) BulkPublish(next BulkPublishFn) BulkPublishFn {
+	return func(req pubsub.BulkPublishRequest) (pubsub.BulkPublishResponse, error) {
+		if !m.limiterFor(req.Topic).Allow() {
+			return pubsub.BulkPublishResponse{}, fmt.Errorf("rate limit exceeded for topic %s", req.Topic)
+		}
+		return next(req)
+	}
+}
+
+func (m *rateLimitMiddleware) Subscribe(next pubsub.Handler) pubsub.Handler {
+	return next
+}
+
+// deadLetterMiddleware republishes a message to a configured dead-letter topic whenever the
+// application's subscribe handler returns an error, instead of letting the component's own retry
+// behavior (if any) be the only recourse.
+type deadLetterMiddleware struct {
+	topic   string
+	log     logger.Logger
+	publish PublishFn
+}
+
+// NewDeadLetterMiddleware returns a PubSubMiddleware that routes failed messages to a dead-letter
+// topic. Metadata key: "deadLetterTopic".
+func NewDeadLetterMiddleware(log logger.Logger, metadata map[string]string) PubSubMiddleware {
+	return &deadLetterMiddleware{topic: metadata["deadLetterTopic"], log: log}
+}
+
+func (m *deadLetterMiddleware) SetPublisher(publish PublishFn) {
+	m.publish = publish
+}
+
+func (m *deadLetterMiddleware) Publish(next PublishFn) PublishFn { return next }
+
+func (m *deadLetterMiddleware) BulkPublish(next BulkPublishFn) BulkPublishFn { return next }
+
+func (m *deadLetterMiddleware) Subscribe(next pubsub.Handler) pubsub.Handler {
+	return func(ctx context.Context, msg *pubsub.NewMessage) error {
+		err := next(ctx, msg)
+		if err == nil || m.topic == "" || m.publish == nil {
+			return err
+		}
+
+		if dlErr := m.publish(&pubsub.PublishRequest{
+			PubsubName: msg.Metadata["pubsubName"],
+			Topic:      m.topic,
+			Data:       msg.Data,
+			Metadata:   msg.Metadata,
+		}); dlErr != nil {
+			m.log.Errorf("failed to route message on topic %s to dead-letter topic %s: %v", msg.Topic, m.topic, dlErr)
+			return err
+		}
+		return nil
+	}
+}
+
+// cloudEventsValidationMiddleware checks that inbound messages are well-formed CloudEvents
+// envelopes before they reach the application handler.
+type cloudEventsValidationMiddleware struct {
+	strict bool
+}
+
+// NewCloudEventsValidationMiddleware returns a PubSubMiddleware that validates the CloudEvents
+// envelope of inbound messages. Metadata key: "strict" (bool, default false) - when true, an
+// invalid envelope is rejected instead of merely being passed through.
+func NewCloudEventsValidationMiddleware(log logger.Logger, metadata map[string]string) PubSubMiddleware {
+	strict, _ := strconv.ParseBool(metadata["strict"])
+	return &cloudEventsValidationMiddleware{strict: strict}
+}
+
+func (m *cloudEventsValidationMiddleware) Publish(next PublishFn) PublishFn { return next }
+
+func (m *cloudEventsValidationMiddleware) BulkPublish(next BulkPublishFn) BulkPublishFn { return next }
+
+func (m *cloudEventsValidationMiddleware) Subscribe(next pubsub.Handler) pubsub.Handler {
+	return func(ctx context.Context, msg *pubsub.NewMessage) error {
+		if err := validateCloudEvent(msg.Data); err != nil && m.strict {
+			return fmt.Errorf("message on topic %s failed CloudEvents validation: %w", msg.Topic, err)
+		}
+		return next(ctx, msg)
+	}
+}
+
+func validateCloudEvent(data []byte) error {
+	var envelope struct {
+		ID          string `json:"id"`
+		Source      string `json:"source"`
+		SpecVersion string `json:"specversion"`
+		Type        string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	var missing []string
+	if envelope.ID == "" {
+		missing = append(missing, "id")
+	}
+	if envelope.Source == "" {
+		missing = append(missing, "source")
+	}
+	if envelope.SpecVersion == "" {
+		missing = append(missing, "specversion")
+	}
+	if envelope.Type == "" {
+		missing = append(missing, "type")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required CloudEvents attributes: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
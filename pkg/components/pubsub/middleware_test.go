@@ -0,0 +1,158 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pubsub
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dapr/components-contrib/pubsub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePubSub is a minimal pubsub.PubSub that records each Publish call to log, so tests can
+// assert on middleware ordering without a real pub/sub component. It deliberately does not
+// implement BulkPublish: fakeBulkPubSub embeds it to add that separately.
+type fakePubSub struct {
+	log *[]string
+	err error
+}
+
+func (f *fakePubSub) Init(metadata pubsub.Metadata) error { return nil }
+func (f *fakePubSub) Features() []pubsub.Feature          { return nil }
+
+func (f *fakePubSub) Publish(req *pubsub.PublishRequest) error {
+	if f.log != nil {
+		*f.log = append(*f.log, "base:publish")
+	}
+	return f.err
+}
+
+func (f *fakePubSub) Subscribe(ctx context.Context, req pubsub.SubscribeRequest, handler pubsub.Handler) error {
+	if f.log != nil {
+		*f.log = append(*f.log, "base:subscribe")
+	}
+	return handler(ctx, &pubsub.NewMessage{Topic: req.Topic})
+}
+
+func (f *fakePubSub) Close() error { return nil }
+
+// fakeBulkPubSub is a fakePubSub that also implements bulkPublisher.
+type fakeBulkPubSub struct {
+	*fakePubSub
+}
+
+func (f *fakeBulkPubSub) BulkPublish(req pubsub.BulkPublishRequest) (pubsub.BulkPublishResponse, error) {
+	return pubsub.BulkPublishResponse{}, nil
+}
+
+// recordingMiddleware appends name:before/name:after around Publish and Subscribe to a shared
+// log, so a test can assert the exact order middlewares run in.
+type recordingMiddleware struct {
+	name string
+	log  *[]string
+}
+
+func (m *recordingMiddleware) Publish(next PublishFn) PublishFn {
+	return func(req *pubsub.PublishRequest) error {
+		*m.log = append(*m.log, m.name+":before")
+		err := next(req)
+		*m.log = append(*m.log, m.name+":after")
+		return err
+	}
+}
+
+func (m *recordingMiddleware) BulkPublish(next BulkPublishFn) BulkPublishFn { return next }
+
+func (m *recordingMiddleware) Subscribe(next pubsub.Handler) pubsub.Handler {
+	return func(ctx context.Context, msg *pubsub.NewMessage) error {
+		*m.log = append(*m.log, m.name+":before")
+		err := next(ctx, msg)
+		*m.log = append(*m.log, m.name+":after")
+		return err
+	}
+}
+
+// capturingPublisherMiddleware stands in for deadLetterMiddleware: it records whatever PublishFn
+// SetPublisher hands it, so a test can confirm that function bypasses the rest of the chain.
+type capturingPublisherMiddleware struct {
+	captured PublishFn
+}
+
+func (m *capturingPublisherMiddleware) SetPublisher(publish PublishFn)               { m.captured = publish }
+func (m *capturingPublisherMiddleware) Publish(next PublishFn) PublishFn             { return next }
+func (m *capturingPublisherMiddleware) BulkPublish(next BulkPublishFn) BulkPublishFn { return next }
+func (m *capturingPublisherMiddleware) Subscribe(next pubsub.Handler) pubsub.Handler { return next }
+
+func TestWrapWithMiddlewareNoChainPassesThrough(t *testing.T) {
+	plain := &fakePubSub{}
+	wrapped := wrapWithMiddleware(plain, nil)
+	assert.Same(t, pubsub.PubSub(plain), wrapped, "an empty chain must return the component unwrapped")
+}
+
+func TestWrapWithMiddlewareBulkPublishCapability(t *testing.T) {
+	var log []string
+	chain := []PubSubMiddleware{&recordingMiddleware{name: "mw", log: &log}}
+
+	plain := wrapWithMiddleware(&fakePubSub{log: &log}, chain)
+	_, ok := plain.(bulkPublisher)
+	assert.False(t, ok, "wrapping a component without BulkPublish must not produce a bulkPublisher")
+
+	bulk := wrapWithMiddleware(&fakeBulkPubSub{fakePubSub: &fakePubSub{log: &log}}, chain)
+	_, ok = bulk.(bulkPublisher)
+	assert.True(t, ok, "wrapping a component with BulkPublish must produce a bulkPublisher")
+}
+
+func TestWrapWithMiddlewarePublishOrder(t *testing.T) {
+	var log []string
+	chain := []PubSubMiddleware{
+		&recordingMiddleware{name: "a", log: &log},
+		&recordingMiddleware{name: "b", log: &log},
+	}
+
+	wrapped := wrapWithMiddleware(&fakePubSub{log: &log}, chain)
+	require.NoError(t, wrapped.Publish(&pubsub.PublishRequest{Topic: "t"}))
+
+	assert.Equal(t, []string{"a:before", "b:before", "base:publish", "b:after", "a:after"}, log,
+		"middlewares must run in registration order on the way in, and the reverse on the way out")
+}
+
+func TestWrapWithMiddlewareSubscribeOrder(t *testing.T) {
+	var log []string
+	chain := []PubSubMiddleware{
+		&recordingMiddleware{name: "a", log: &log},
+		&recordingMiddleware{name: "b", log: &log},
+	}
+
+	wrapped := wrapWithMiddleware(&fakePubSub{log: &log}, chain)
+	handler := func(ctx context.Context, msg *pubsub.NewMessage) error { return nil }
+	require.NoError(t, wrapped.Subscribe(context.Background(), pubsub.SubscribeRequest{Topic: "t"}, handler))
+
+	assert.Equal(t, []string{"base:subscribe", "a:before", "b:before", "b:after", "a:after"}, log,
+		"subscribe middlewares must wrap the handler in registration order, same as Publish")
+}
+
+func TestWrapWithMiddlewareSetPublisherBypassesChain(t *testing.T) {
+	var log []string
+	capturer := &capturingPublisherMiddleware{}
+	chain := []PubSubMiddleware{capturer, &recordingMiddleware{name: "a", log: &log}}
+
+	wrapWithMiddleware(&fakePubSub{log: &log}, chain)
+	require.NotNil(t, capturer.captured, "SetPublisher must be called with the chain built")
+
+	require.NoError(t, capturer.captured(&pubsub.PublishRequest{Topic: "t"}))
+	assert.Equal(t, []string{"base:publish"}, log,
+		"the publisher handed to a publisherAware middleware must call straight through to the component, bypassing the rest of the chain")
+}
@@ -14,18 +14,29 @@ limitations under the License.
 package pubsub
 
 import (
+	"context"
 	"strings"
+	"sync"
 
 	"github.com/pkg/errors"
 
 	"github.com/dapr/components-contrib/pubsub"
 	"github.com/dapr/dapr/pkg/components"
+	"github.com/dapr/dapr/pkg/resiliency"
 	"github.com/dapr/kit/logger"
 )
 
 type Registry struct {
-	Logger       logger.Logger
+	Logger     logger.Logger
+	Resiliency resiliency.Provider
+
 	messageBuses map[string]func(logger.Logger) pubsub.PubSub
+
+	middlewareFactories map[string]MiddlewareFactory
+	middlewareChain     []PubSubMiddleware
+
+	instancesMu sync.Mutex
+	instances   map[string]pubsub.PubSub
 }
 
 // DefaultRegistry is the singleton with the registry.
@@ -38,7 +49,9 @@ func init() {
 // NewRegistry returns a new pub sub registry.
 func NewRegistry() *Registry {
 	return &Registry{
-		messageBuses: map[string]func(logger.Logger) pubsub.PubSub{},
+		messageBuses:        map[string]func(logger.Logger) pubsub.PubSub{},
+		middlewareFactories: map[string]MiddlewareFactory{},
+		instances:           map[string]pubsub.PubSub{},
 	}
 }
 
@@ -49,12 +62,48 @@ func (p *Registry) RegisterComponent(componentFactory func(logger.Logger) pubsub
 	}
 }
 
-// Create instantiates a pub/sub based on `name`.
-func (p *Registry) Create(name, version string) (pubsub.PubSub, error) {
-	if method, ok := p.getPubSub(name, version); ok {
-		return method(), nil
+// RegisterMiddleware adds a middleware factory to the registry under name, so it can later be
+// enabled via ConfigureMiddleware.
+func (p *Registry) RegisterMiddleware(name string, factory MiddlewareFactory) {
+	p.middlewareFactories[strings.ToLower(name)] = factory
+}
+
+// MiddlewareSpec selects a registered middleware, by name, and the metadata to build it with.
+type MiddlewareSpec struct {
+	Name     string
+	Metadata map[string]string
+}
+
+// ConfigureMiddleware builds the middleware chain that Create applies to every pub/sub instance
+// it creates from this point on, in the given order.
+func (p *Registry) ConfigureMiddleware(specs []MiddlewareSpec) error {
+	chain := make([]PubSubMiddleware, 0, len(specs))
+	for _, spec := range specs {
+		factory, ok := p.middlewareFactories[strings.ToLower(spec.Name)]
+		if !ok {
+			return errors.Errorf("couldn't find pub/sub middleware %s", spec.Name)
+		}
+		chain = append(chain, factory(p.Logger, spec.Metadata))
+	}
+	p.middlewareChain = chain
+	return nil
+}
+
+// Create instantiates a pub/sub based on `name`, wraps it with the configured middleware chain,
+// and tracks the result under componentName for HealthCheck.
+func (p *Registry) Create(name, version, componentName string) (pubsub.PubSub, error) {
+	method, ok := p.getPubSub(name, version)
+	if !ok {
+		return nil, errors.Errorf("couldn't find message bus %s/%s", name, version)
 	}
-	return nil, errors.Errorf("couldn't find message bus %s/%s", name, version)
+
+	ps := wrapWithMiddleware(method(), p.middlewareChain)
+
+	p.instancesMu.Lock()
+	p.instances[componentName] = ps
+	p.instancesMu.Unlock()
+
+	return ps, nil
 }
 
 func (p *Registry) getPubSub(name, version string) (func() pubsub.PubSub, bool) {
@@ -0,0 +1,104 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+const (
+	AllowAccess = "allow"
+	DenyAccess  = "deny"
+)
+
+// SecretsScope defines the scope for secrets.
+type SecretsScope struct {
+	DefaultAccess  string   `json:"defaultAccess,omitempty" yaml:"defaultAccess,omitempty"`
+	AllowedSecrets []string `json:"allowedSecrets,omitempty" yaml:"allowedSecrets,omitempty"`
+	DeniedSecrets  []string `json:"deniedSecrets,omitempty" yaml:"deniedSecrets,omitempty"`
+
+	// CallerPolicies lets a narrower SecretsScope be granted to specific callers, identified by
+	// their app ID or SPIFFE ID. A caller that has no entry here falls back to the scope's own
+	// DefaultAccess/AllowedSecrets/DeniedSecrets. A caller's DefaultAccess/AllowedSecrets replace
+	// the scope's own for that caller, but DeniedSecrets is merged (union), never replaced: a
+	// store-level deny always applies to every caller, so a CallerPolicies entry can grant a caller
+	// keys beyond the scope's own allow rules, but never un-deny a key the store operator explicitly
+	// blacklisted for everyone.
+	CallerPolicies map[string]SecretsScope `json:"callerPolicies,omitempty" yaml:"callerPolicies,omitempty"`
+}
+
+// ForCaller returns the effective SecretsScope to apply for the given caller identity (app ID or
+// SPIFFE ID). If the caller has no dedicated policy, the scope itself is returned unchanged.
+func (c SecretsScope) ForCaller(callerID string) SecretsScope {
+	if callerID == "" || len(c.CallerPolicies) == 0 {
+		return c
+	}
+	scoped, ok := c.CallerPolicies[callerID]
+	if !ok {
+		return c
+	}
+	return SecretsScope{
+		DefaultAccess:  scoped.DefaultAccess,
+		AllowedSecrets: scoped.AllowedSecrets,
+		DeniedSecrets:  mergeDenied(c.DeniedSecrets, scoped.DeniedSecrets),
+	}
+}
+
+// mergeDenied unions two DeniedSecrets lists, so a key denied by either is denied by the result.
+func mergeDenied(a, b []string) []string {
+	if len(a) == 0 {
+		return b
+	}
+	if len(b) == 0 {
+		return a
+	}
+	seen := make(map[string]struct{}, len(a)+len(b))
+	merged := make([]string, 0, len(a)+len(b))
+	for _, list := range [][]string{a, b} {
+		for _, key := range list {
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			merged = append(merged, key)
+		}
+	}
+	return merged
+}
+
+// IsSecretAllowed Check if the secret is allowed to be accessed.
+func (c SecretsScope) IsSecretAllowed(key string) bool {
+	// By default, set allow access for the secret store.
+	access := AllowAccess
+	if c.DefaultAccess != "" {
+		access = c.DefaultAccess
+	}
+
+	// If the deniedSecrets list contains the key, then access is denied irrespective of the default access.
+	if stringSliceContains(c.DeniedSecrets, key) {
+		return false
+	}
+	// If the allowedSecrets list is not empty, then only the keys it lists are allowed.
+	if len(c.AllowedSecrets) != 0 {
+		return stringSliceContains(c.AllowedSecrets, key)
+	}
+
+	// Otherwise the default access of the secret store decides.
+	return access == AllowAccess
+}
+
+func stringSliceContains(list []string, key string) bool {
+	for _, item := range list {
+		if item == key {
+			return true
+		}
+	}
+	return false
+}
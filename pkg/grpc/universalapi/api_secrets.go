@@ -0,0 +1,185 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package universalapi
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/dapr/components-contrib/secretstores"
+	"github.com/dapr/dapr/pkg/cache"
+	"github.com/dapr/dapr/pkg/config"
+	"github.com/dapr/dapr/pkg/messages"
+	runtimev1pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+	"github.com/dapr/dapr/pkg/resiliency"
+)
+
+// refreshCache is the GetSecretRequest metadata key that forces a secret store round-trip instead
+// of serving the value from the in-memory secret cache.
+const refreshCache = "refreshCache"
+
+// rotateCacheKey is the GetSecretRequest metadata key that rotates the secret cache's data
+// encryption key before serving the request, analogous to refreshCache.
+const rotateCacheKey = "rotateCacheKey"
+
+// GetSecret gets the value for a given secret from a secret store.
+func (a *UniversalAPI) GetSecret(ctx context.Context, in *runtimev1pb.GetSecretRequest) (*runtimev1pb.GetSecretResponse, error) {
+	if len(a.SecretStores) == 0 {
+		err := messages.ErrSecretStoreNotConfigured
+		a.Logger.Debug(err)
+		return &runtimev1pb.GetSecretResponse{}, err
+	}
+
+	secretStoreName := in.StoreName
+
+	store, ok := a.SecretStores[secretStoreName]
+	if !ok {
+		return &runtimev1pb.GetSecretResponse{}, a.secretAccessError(secretStoreName, in.Key, "secret store %s does not exist", secretStoreName)
+	}
+
+	callerID := callerIDFromContext(ctx)
+	if !a.isSecretAllowed(secretStoreName, callerID, in.Key) {
+		return &runtimev1pb.GetSecretResponse{}, a.secretAccessError(secretStoreName, in.Key, "access denied by policy to get %s from %s", in.Key, secretStoreName)
+	}
+
+	if cache.Enabled(secretStoreName) {
+		if in.Metadata[rotateCacheKey] == "true" {
+			if rerr := cache.Rotate(ctx, secretStoreName); rerr != nil {
+				a.Logger.Warnf("failed to rotate secret cache key for store %s: %v", secretStoreName, rerr)
+			}
+		}
+		if in.Metadata[refreshCache] != "true" {
+			if value, ok := cache.Get(secretStoreName, in.Key); ok {
+				return &runtimev1pb.GetSecretResponse{Data: map[string]string{in.Key: value}}, nil
+			}
+		}
+	}
+
+	req := secretstores.GetSecretRequest{
+		Name:     in.Key,
+		Metadata: in.Metadata,
+	}
+
+	policyRunner := resiliency.NewRunner[secretstores.GetSecretResponse](ctx,
+		a.Resiliency.ComponentOutboundPolicy(secretStoreName, resiliency.Secretstore),
+	)
+	resp, err := policyRunner(func(ctx context.Context) (secretstores.GetSecretResponse, error) {
+		return store.GetSecret(req)
+	})
+	if err != nil {
+		err = status.Errorf(codes.Internal, "failed getting secret with key %s from secret store %s: %v", in.Key, secretStoreName, err)
+		a.Logger.Debug(err)
+		return &runtimev1pb.GetSecretResponse{}, err
+	}
+
+	if value, ok := resp.Data[in.Key]; ok {
+		cache.Set(secretStoreName, in.Key, value)
+	}
+
+	return &runtimev1pb.GetSecretResponse{Data: resp.Data}, nil
+}
+
+// GetBulkSecret gets the secret for a given store, in bulk.
+func (a *UniversalAPI) GetBulkSecret(ctx context.Context, in *runtimev1pb.GetBulkSecretRequest) (*runtimev1pb.GetBulkSecretResponse, error) {
+	if len(a.SecretStores) == 0 {
+		err := messages.ErrSecretStoreNotConfigured
+		a.Logger.Debug(err)
+		return &runtimev1pb.GetBulkSecretResponse{}, err
+	}
+
+	secretStoreName := in.StoreName
+
+	store, ok := a.SecretStores[secretStoreName]
+	if !ok {
+		return &runtimev1pb.GetBulkSecretResponse{}, a.secretAccessError(secretStoreName, "", "secret store %s does not exist", secretStoreName)
+	}
+
+	req := secretstores.BulkGetSecretRequest{
+		Metadata: in.Metadata,
+	}
+
+	policyRunner := resiliency.NewRunner[secretstores.BulkGetSecretResponse](ctx,
+		a.Resiliency.ComponentOutboundPolicy(secretStoreName, resiliency.Secretstore),
+	)
+	resp, err := policyRunner(func(ctx context.Context) (secretstores.BulkGetSecretResponse, error) {
+		return store.BulkGetSecret(req)
+	})
+	if err != nil {
+		err = status.Errorf(codes.Internal, "failed getting secrets from secret store %s: %v", secretStoreName, err)
+		a.Logger.Debug(err)
+		return &runtimev1pb.GetBulkSecretResponse{}, err
+	}
+
+	callerID := callerIDFromContext(ctx)
+	filtered := map[string]*runtimev1pb.SecretResponse{}
+	for key, secrets := range resp.Data {
+		if !a.isSecretAllowed(secretStoreName, callerID, key) {
+			continue
+		}
+		filtered[key] = &runtimev1pb.SecretResponse{Secrets: secrets}
+	}
+
+	return &runtimev1pb.GetBulkSecretResponse{Data: filtered}, nil
+}
+
+// isSecretAllowed returns whether callerID is allowed to read key from the given secret store,
+// under the store's configured SecretsScope (and, when present, callerID's own CallerPolicies
+// override of that scope).
+func (a *UniversalAPI) isSecretAllowed(storeName, callerID, key string) bool {
+	scope, ok := a.SecretsConfiguration[storeName]
+	if !ok {
+		// No configuration present for this store: allow by default.
+		return true
+	}
+	return scope.ForCaller(callerID).IsSecretAllowed(key)
+}
+
+// secretAccessError logs the real reason a secret request failed and returns a single,
+// unified gRPC status for it. Both an unknown secret store and a denied key are reported to the
+// caller as PermissionDenied, so that the distinct InvalidArgument code previously used for an
+// unknown store can no longer be used to enumerate which secret stores are configured.
+func (a *UniversalAPI) secretAccessError(storeName, key, logFormat string, logArgs ...any) error {
+	a.Logger.Debugf(logFormat, logArgs...)
+	err := status.Errorf(codes.PermissionDenied, "%v", messages.ErrPermissionDenied)
+	return err
+}
+
+// callerIDFromContext derives the identity of the calling app from the SPIFFE ID presented on the
+// caller's verified mTLS client certificate. There is deliberately no fallback to anything the
+// caller merely claims about itself (e.g. a gRPC metadata header): CallerPolicies grants a caller
+// a scope other callers don't have, so honoring an unverified identity would let any caller
+// self-declare its way into someone else's grant. A caller this can't identify gets the
+// SecretsScope's own DefaultAccess, the same as before CallerPolicies existed.
+func callerIDFromContext(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return ""
+	}
+	for _, cert := range tlsInfo.State.PeerCertificates {
+		for _, uri := range cert.URIs {
+			if uri.Scheme == "spiffe" {
+				return uri.String()
+			}
+		}
+	}
+	return ""
+}
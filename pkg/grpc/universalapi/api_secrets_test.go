@@ -15,12 +15,17 @@ package universalapi
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/url"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 
 	"github.com/dapr/components-contrib/secretstores"
@@ -37,6 +42,23 @@ import (
 
 var testLogger = logger.NewLogger("testlogger")
 
+// peerContextWithSPIFFEID returns a context carrying a gRPC peer whose verified mTLS client
+// certificate presents spiffeID as a URI SAN, the same shape callerIDFromContext expects from a
+// real mTLS connection.
+func peerContextWithSPIFFEID(ctx context.Context, spiffeID string) context.Context {
+	u, err := url.Parse(spiffeID)
+	if err != nil {
+		panic(err)
+	}
+	return peer.NewContext(ctx, &peer.Peer{
+		AuthInfo: credentials.TLSInfo{
+			State: tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{{URIs: []*url.URL{u}}},
+			},
+		},
+	})
+}
+
 var testResiliency = &v1alpha1.Resiliency{
 	Spec: v1alpha1.ResiliencySpec{
 		Policies: v1alpha1.Policies{
@@ -114,10 +136,25 @@ func TestGetSecret(t *testing.T) {
 		"store2": {
 			DefaultAccess:  config.DenyAccess,
 			AllowedSecrets: []string{"good-key"},
+			CallerPolicies: map[string]config.SecretsScope{
+				"spiffe://example.org/ns/default/scopedApp": {
+					DefaultAccess:  config.DenyAccess,
+					AllowedSecrets: []string{"good-key", "store2-caller-only-key"},
+				},
+			},
 		},
 		"store3": {
 			DefaultAccess:  config.AllowAccess,
 			AllowedSecrets: []string{"error-key", "good-key"},
+			DeniedSecrets:  []string{"team-b-only"},
+			CallerPolicies: map[string]config.SecretsScope{
+				"spiffe://example.org/ns/default/scopedApp": {
+					DefaultAccess: config.DenyAccess,
+					// Lists "team-b-only" too, to prove a caller policy can't un-deny a key the store
+					// itself blacklisted: DeniedSecrets is merged (union), never replaced.
+					AllowedSecrets: []string{"good-key", "caller-only-key", "team-b-only"},
+				},
+			},
 		},
 	}
 	expectedResponse := "life is good"
@@ -131,6 +168,7 @@ func TestGetSecret(t *testing.T) {
 		testName         string
 		storeName        string
 		key              string
+		callerAppID      string
 		errorExcepted    bool
 		expectedResponse string
 		expectedError    codes.Code
@@ -194,7 +232,63 @@ func TestGetSecret(t *testing.T) {
 			key:              "key",
 			errorExcepted:    true,
 			expectedResponse: "",
-			expectedError:    codes.InvalidArgument,
+			expectedError:    codes.PermissionDenied,
+		},
+		{
+			testName:         "Caller with scoped policy can read key not allowed by store default",
+			storeName:        restrictedStore,
+			key:              "caller-only-key",
+			callerAppID:      "spiffe://example.org/ns/default/scopedApp",
+			errorExcepted:    false,
+			expectedResponse: "",
+		},
+		{
+			testName:         "Caller with scoped policy is denied a key allowed by store default",
+			storeName:        restrictedStore,
+			key:              "error-key",
+			callerAppID:      "spiffe://example.org/ns/default/scopedApp",
+			errorExcepted:    true,
+			expectedResponse: "",
+			expectedError:    codes.PermissionDenied,
+		},
+		{
+			testName:         "Caller with no scoped policy falls back to store default",
+			storeName:        restrictedStore,
+			key:              "good-key",
+			callerAppID:      "spiffe://example.org/ns/default/unscopedApp",
+			errorExcepted:    false,
+			expectedResponse: expectedResponse,
+		},
+		{
+			// A caller policy can widen access, but never past a store-level deny: even though
+			// scopedApp's own policy lists "team-b-only" as allowed, the store's DeniedSecrets always
+			// wins, so this must still be denied.
+			testName:         "Store-level deny wins even for a caller with a scoped policy",
+			storeName:        restrictedStore,
+			key:              "team-b-only",
+			callerAppID:      "spiffe://example.org/ns/default/scopedApp",
+			errorExcepted:    true,
+			expectedResponse: "",
+			expectedError:    codes.PermissionDenied,
+		},
+		{
+			testName:         "Verified caller with scoped policy can read a key beyond store default",
+			storeName:        deniedStoreName,
+			key:              "store2-caller-only-key",
+			callerAppID:      "spiffe://example.org/ns/default/scopedApp",
+			errorExcepted:    false,
+			expectedResponse: "",
+		},
+		{
+			// An unverified claim to be "scopedApp" (no mTLS peer, so callerIDFromContext returns "")
+			// must not grant scopedApp's CallerPolicies grant for a key the store's own default
+			// denies - otherwise any caller could self-declare its way into another app's scope.
+			testName:         "Unverified caller cannot claim another app's scoped policy",
+			storeName:        deniedStoreName,
+			key:              "store2-caller-only-key",
+			errorExcepted:    true,
+			expectedResponse: "",
+			expectedError:    codes.PermissionDenied,
 		},
 	}
 
@@ -213,7 +307,11 @@ func TestGetSecret(t *testing.T) {
 				StoreName: tt.storeName,
 				Key:       tt.key,
 			}
-			resp, err := fakeAPI.GetSecret(context.Background(), req)
+			ctx := context.Background()
+			if tt.callerAppID != "" {
+				ctx = peerContextWithSPIFFEID(ctx, tt.callerAppID)
+			}
+			resp, err := fakeAPI.GetSecret(ctx, req)
 
 			if !tt.errorExcepted {
 				assert.NoError(t, err, "Expected no error")
@@ -391,6 +489,14 @@ func TestSecretCache(t *testing.T) {
 	assert.Equal(t, resp.Data["good-key"], "life is good", "Expected responses to be same")
 	assert.Equal(t, 2, daprt.GetSecretCount, "Expected get count add when refresh cache is true")
 
+	// test rotate cache key: rotating the data encryption key must not force a store round-trip,
+	// since the entry sealed under the old epoch is still decryptable and gets lazily re-sealed.
+	req.Metadata = map[string]string{rotateCacheKey: "true"}
+	resp, err = fakeAPI.GetSecret(context.Background(), req)
+	assert.NoError(t, err, "Expected no error")
+	assert.Equal(t, resp.Data["good-key"], "life is good", "Expected responses to be same")
+	assert.Equal(t, 2, daprt.GetSecretCount, "Expected get count not to add when rotating cache key")
+
 	// test no cache
 	req = &runtimev1pb.GetSecretRequest{
 		StoreName: storeName2,
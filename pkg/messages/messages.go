@@ -0,0 +1,32 @@
+/*
+Copyright 2021 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package messages contains the sentinel errors shared by the Dapr APIs.
+package messages
+
+import "errors"
+
+var (
+	// ErrSecretStoreNotConfigured is returned when the caller did not configure any secret store.
+	ErrSecretStoreNotConfigured = errors.New("secret store is not configured")
+
+	// ErrSecretStoreNotFound describes a request for a secret store that was not found. The secrets
+	// APIs do not return it: they answer an unknown store with the same PermissionDenied response
+	// used for a denied key, so that a caller cannot use the error code to enumerate which secret
+	// stores are configured.
+	ErrSecretStoreNotFound = errors.New("secret store is not found")
+
+	// ErrPermissionDenied is returned when a caller is not allowed to access the requested secret,
+	// or when the requested secret store does not exist.
+	ErrPermissionDenied = errors.New("access denied by policy to get the secret")
+)
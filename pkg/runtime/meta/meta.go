@@ -14,27 +14,41 @@ limitations under the License.
 package meta
 
 import (
+	"context"
 	"fmt"
-	"log"
 	"strconv"
 	"strings"
-
-	"github.com/google/uuid"
+	"sync"
 
 	"github.com/dapr/components-contrib/metadata"
 	"github.com/dapr/dapr/pkg/apis/common"
 	compapi "github.com/dapr/dapr/pkg/apis/components/v1alpha1"
 	"github.com/dapr/dapr/pkg/modes"
+	"github.com/dapr/kit/logger"
 )
 
 const WasmStrictSandboxMetadataKey = "strictSandbox"
 
+// SecretGetter resolves a key from a named secret store, following the same scoping and
+// resiliency path as the Dapr secrets API, so that the `secret` template function is subject to
+// the same access control as any other caller of that store.
+type SecretGetter func(ctx context.Context, storeName, key string) (string, error)
+
 type Options struct {
 	ID            string
 	PodName       string
 	Namespace     string
 	StrictSandbox *bool
 	Mode          modes.DaprMode
+	// SecretGetter resolves `{{ secret "store" "key" }}` references in component metadata. If nil,
+	// the `secret` template function returns an error when used.
+	SecretGetter SecretGetter
+	// Logger receives the warnings Validate emits for EnforcementWarn/EnforcementDryRun policy
+	// violations. Defaults to a package logger if nil.
+	Logger logger.Logger
+	// Policies is the initial ComponentPolicy set consulted by Validate. It can be replaced
+	// later with SetPolicies.
+	Policies []ComponentPolicy
 }
 
 type Meta struct {
@@ -43,43 +57,56 @@ type Meta struct {
 	namespace     string
 	strictSandbox *bool
 	mode          modes.DaprMode
+	secretGetter  SecretGetter
+	log           logger.Logger
+
+	policiesMu sync.RWMutex
+	policies   []ComponentPolicy
 }
 
 func New(options Options) *Meta {
+	log := options.Logger
+	if log == nil {
+		log = defaultLogger
+	}
 	return &Meta{
 		podName:       options.PodName,
 		namespace:     options.Namespace,
 		strictSandbox: options.StrictSandbox,
 		id:            options.ID,
+		secretGetter:  options.SecretGetter,
+		log:           log,
+		policies:      options.Policies,
 	}
 }
 
-func (m *Meta) ToBaseMetadata(comp compapi.Component) metadata.Base {
+var defaultLogger = logger.NewLogger("dapr.runtime.meta")
+
+// ToBaseMetadata renders comp's metadata properties through the template pipeline (see
+// template.go) and returns the resulting metadata.Base. It returns an error rather than
+// terminating the process if a property can't be resolved, so the runtime can surface the failure
+// through the component-loading diagnostic stream instead of killing the sidecar.
+func (m *Meta) ToBaseMetadata(ctx context.Context, comp compapi.Component) (metadata.Base, error) {
+	props, err := m.convertItemsToProps(ctx, comp.Spec.Metadata)
+	if err != nil {
+		return metadata.Base{}, err
+	}
 	return metadata.Base{
-		Properties: m.convertItemsToProps(comp.Spec.Metadata),
+		Properties: props,
 		Name:       comp.Name,
-	}
+	}, nil
 }
 
-func (m *Meta) convertItemsToProps(items []common.NameValuePair) map[string]string {
+func (m *Meta) convertItemsToProps(ctx context.Context, items []common.NameValuePair) (map[string]string, error) {
 	properties := map[string]string{}
 	for _, c := range items {
-		val := c.Value.String()
-		for strings.Contains(val, "{uuid}") {
-			val = strings.Replace(val, "{uuid}", uuid.New().String(), 1)
-		}
-		if strings.Contains(val, "{podName}") {
-			if m.podName == "" {
-				// TODO: @joshvanl: return error here rather than panicing.
-				log.Fatalf("failed to parse metadata: property %s refers to {podName} but podName is not set", c.Name)
-			}
-			val = strings.ReplaceAll(val, "{podName}", m.podName)
+		val, err := m.render(ctx, c.Value.String())
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse metadata property %s: %w", c.Name, err)
 		}
-		val = strings.ReplaceAll(val, "{namespace}", fmt.Sprintf("%s.%s", m.namespace, m.id))
-		val = strings.ReplaceAll(val, "{appID}", m.id)
 		properties[c.Name] = val
 	}
-	return properties
+	return properties, nil
 }
 
 func (m *Meta) AuthSecretStoreOrDefault(resource Resource) string {
@@ -93,27 +120,34 @@ func (m *Meta) AuthSecretStoreOrDefault(resource Resource) string {
 	return secretStore
 }
 
+// ContainsNamespace reports whether any of items references the `{namespace}` placeholder, in
+// either its legacy syntax or its template syntax spelled exactly `{{ namespace }}`; other
+// spellings of the template action, such as `{{namespace}}`, are not detected.
 func ContainsNamespace(items []common.NameValuePair) bool {
 	for _, c := range items {
 		val := c.Value.String()
-		if strings.Contains(val, "{namespace}") {
+		if strings.Contains(val, "{namespace}") || strings.Contains(val, namespaceFunc) {
 			return true
 		}
 	}
 	return false
 }
 
-// AddWasmStrictSandbox adds wasm strict sandbox configuration to metadata.
+// AddWasmStrictSandbox adds wasm strict sandbox configuration to metadata, driven solely by the
+// global StrictSandbox option. A ComponentPolicy that requires strictSandbox=true is deliberately
+// never auto-applied here: Validate is what enforces that policy (deny, dryrun or warn), and if
+// this function patched the metadata to satisfy it first, Validate would never see the
+// component's real pre-policy value and could never actually deny or dry-run-tag it.
 func (m *Meta) AddWasmStrictSandbox(comp *compapi.Component) {
-	// If the global strict sandbox is not set, do nothing.
 	if m.strictSandbox == nil {
 		return
 	}
+	want := *m.strictSandbox
 
 	// If the metadata already contains the strict sandbox key, update the value to global strict sandbox config.
 	for _, c := range comp.Spec.Metadata {
 		if strings.EqualFold(c.Name, WasmStrictSandboxMetadataKey) {
-			c.SetValue([]byte(strconv.FormatBool(*m.strictSandbox)))
+			c.SetValue([]byte(strconv.FormatBool(want)))
 			return
 		}
 	}
@@ -122,6 +156,6 @@ func (m *Meta) AddWasmStrictSandbox(comp *compapi.Component) {
 	sandbox := common.NameValuePair{
 		Name: WasmStrictSandboxMetadataKey,
 	}
-	sandbox.SetValue([]byte(strconv.FormatBool(*m.strictSandbox)))
+	sandbox.SetValue([]byte(strconv.FormatBool(want)))
 	comp.Spec.Metadata = append(comp.Spec.Metadata, sandbox)
 }
@@ -0,0 +1,29 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package meta
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// policyEnforcementTotal counts every ComponentPolicy violation Validate observes, labeled by the
+// violating component and the enforcement action that was taken for it.
+var policyEnforcementTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "dapr",
+	Subsystem: "component_policy",
+	Name:      "enforcement_total",
+	Help:      "Number of component policy violations observed, by component and enforcement action.",
+}, []string{"namespace", "name", "action"})
+
+func init() {
+	prometheus.MustRegister(policyEnforcementTotal)
+}
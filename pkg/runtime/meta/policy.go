@@ -0,0 +1,185 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package meta
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dapr/dapr/pkg/apis/common"
+	compapi "github.com/dapr/dapr/pkg/apis/components/v1alpha1"
+)
+
+// EnforcementAction is the action taken when a component violates a ComponentPolicy.
+type EnforcementAction string
+
+const (
+	// EnforcementDeny rejects a violating component outright: Validate returns an error and the
+	// component is not loaded.
+	EnforcementDeny EnforcementAction = "deny"
+	// EnforcementWarn lets a violating component load unchanged, but logs and counts the
+	// violation so operators can judge whether it's safe to move to EnforcementDeny.
+	EnforcementWarn EnforcementAction = "warn"
+	// EnforcementDryRun lets a violating component load, but tags it so the runtime can refuse to
+	// route dataplane traffic to it while still populating introspection endpoints.
+	EnforcementDryRun EnforcementAction = "dryrun"
+)
+
+// DryRunMetadataKey is the metadata key Validate sets on a component that was allowed to load
+// under an EnforcementDryRun policy violation; IsDryRun reads it back. Setting the tag does not by
+// itself stop dataplane traffic to the component: nothing in this package withholds traffic.
+const DryRunMetadataKey = "dapr.io/dry-run"
+
+// ComponentPolicy constrains the metadata a component is allowed to be loaded with. It applies to
+// every component whose type matches Selector.
+type ComponentPolicy struct {
+	// Selector limits which components this policy applies to: an exact component type (e.g.
+	// "bindings.wasm"), a prefix ending in "*" (e.g. "bindings.wasm.*"), or "*"/"" for all
+	// components.
+	Selector string
+	// RequiredMetadata maps a metadata key to the value it must have for the component to comply
+	// with this policy (e.g. {"strictSandbox": "true"}).
+	RequiredMetadata map[string]string
+	// EnforcementAction is what happens when a component matching Selector doesn't satisfy
+	// RequiredMetadata: "deny", "warn" or "dryrun" (case-insensitive, surrounding whitespace
+	// ignored). Defaults to EnforcementWarn if empty. Any other value makes Validate return a
+	// configuration error rather than silently falling back to warn - an operator who typos
+	// "Deny"/"dney" must find out immediately, not discover during an incident that enforcement
+	// was never actually on.
+	EnforcementAction EnforcementAction
+}
+
+// normalizedAction returns p.EnforcementAction normalized for comparison (lowercased, trimmed),
+// defaulting empty to EnforcementWarn, or an error if it's set to something that isn't one of
+// EnforcementDeny/EnforcementWarn/EnforcementDryRun.
+func (p ComponentPolicy) normalizedAction() (EnforcementAction, error) {
+	action := EnforcementAction(strings.ToLower(strings.TrimSpace(string(p.EnforcementAction))))
+	switch action {
+	case "":
+		return EnforcementWarn, nil
+	case EnforcementDeny, EnforcementWarn, EnforcementDryRun:
+		return action, nil
+	default:
+		return "", fmt.Errorf("policy %q has unrecognized enforcementAction %q: must be %q, %q or %q",
+			p.Selector, p.EnforcementAction, EnforcementDeny, EnforcementWarn, EnforcementDryRun)
+	}
+}
+
+func (p ComponentPolicy) matches(comp *compapi.Component) bool {
+	if p.Selector == "" || p.Selector == "*" {
+		return true
+	}
+	if strings.HasSuffix(p.Selector, "*") {
+		return strings.HasPrefix(comp.Spec.Type, strings.TrimSuffix(p.Selector, "*"))
+	}
+	return comp.Spec.Type == p.Selector
+}
+
+func (p ComponentPolicy) violations(comp *compapi.Component) []string {
+	values := make(map[string]string, len(comp.Spec.Metadata))
+	for _, c := range comp.Spec.Metadata {
+		values[strings.ToLower(c.Name)] = c.Value.String()
+	}
+
+	var violations []string
+	for key, want := range p.RequiredMetadata {
+		if got, ok := values[strings.ToLower(key)]; !ok || !strings.EqualFold(got, want) {
+			violations = append(violations, fmt.Sprintf("metadata %s must be %q", key, want))
+		}
+	}
+	return violations
+}
+
+// SetPolicies replaces the ComponentPolicy set consulted by Validate. It is safe to call while
+// components are being loaded, so an operator can stage a policy in EnforcementWarn and later
+// flip it to EnforcementDeny (or back) without restarting the sidecar.
+func (m *Meta) SetPolicies(policies []ComponentPolicy) {
+	m.policiesMu.Lock()
+	defer m.policiesMu.Unlock()
+	m.policies = policies
+}
+
+func (m *Meta) policySnapshot() []ComponentPolicy {
+	m.policiesMu.RLock()
+	defer m.policiesMu.RUnlock()
+	return m.policies
+}
+
+// Validate checks comp against the configured ComponentPolicy set. A component that violates a
+// EnforcementDeny policy is rejected with an error, as is a policy whose EnforcementAction isn't
+// one of EnforcementDeny/EnforcementWarn/EnforcementDryRun - an invalid policy is a configuration
+// error, not something to silently downgrade to warn. A violation of an EnforcementWarn policy is
+// logged, with the component's namespace/name as resource reference, and counted in
+// policyEnforcementTotal, but comp is otherwise unchanged. A violation of an EnforcementDryRun
+// policy is also logged and counted, and additionally tags comp with DryRunMetadataKey (see
+// IsDryRun); comp is still loaded.
+func (m *Meta) Validate(comp *compapi.Component) error {
+	for _, policy := range m.policySnapshot() {
+		if !policy.matches(comp) {
+			continue
+		}
+
+		violations := policy.violations(comp)
+		if len(violations) == 0 {
+			continue
+		}
+
+		reason := strings.Join(violations, "; ")
+		action, err := policy.normalizedAction()
+		if err != nil {
+			return fmt.Errorf("component %s/%s: %w", comp.Namespace, comp.Name, err)
+		}
+
+		switch action {
+		case EnforcementDeny:
+			policyEnforcementTotal.WithLabelValues(comp.Namespace, comp.Name, string(EnforcementDeny)).Inc()
+			return fmt.Errorf("component %s/%s violates policy %q: %s", comp.Namespace, comp.Name, policy.Selector, reason)
+		case EnforcementDryRun:
+			policyEnforcementTotal.WithLabelValues(comp.Namespace, comp.Name, string(EnforcementDryRun)).Inc()
+			m.logPolicyViolation(comp, policy, action, reason)
+			markDryRun(comp)
+		default: // EnforcementWarn
+			policyEnforcementTotal.WithLabelValues(comp.Namespace, comp.Name, string(EnforcementWarn)).Inc()
+			m.logPolicyViolation(comp, policy, action, reason)
+		}
+	}
+	return nil
+}
+
+func (m *Meta) logPolicyViolation(comp *compapi.Component, policy ComponentPolicy, action EnforcementAction, reason string) {
+	m.log.Warnf("component %s/%s violates policy %q (%s): %s", comp.Namespace, comp.Name, policy.Selector, action, reason)
+}
+
+// IsDryRun reports whether comp was tagged by Validate as loaded under an EnforcementDryRun policy
+// violation.
+func IsDryRun(comp compapi.Component) bool {
+	for _, c := range comp.Spec.Metadata {
+		if strings.EqualFold(c.Name, DryRunMetadataKey) {
+			return strings.EqualFold(c.Value.String(), "true")
+		}
+	}
+	return false
+}
+
+func markDryRun(comp *compapi.Component) {
+	for _, c := range comp.Spec.Metadata {
+		if strings.EqualFold(c.Name, DryRunMetadataKey) {
+			c.SetValue([]byte("true"))
+			return
+		}
+	}
+	tag := common.NameValuePair{Name: DryRunMetadataKey}
+	tag.SetValue([]byte("true"))
+	comp.Spec.Metadata = append(comp.Spec.Metadata, tag)
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package meta
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/google/uuid"
+)
+
+// allowEnvTemplateVar is the environment variable holding the comma-separated allowlist of names
+// the `env` template function may read. It exists so a component can't read arbitrary process
+// environment variables (which may include secrets never meant for components) just by being
+// configured with the right metadata value.
+const allowEnvTemplateVar = "DAPR_ALLOW_ENV_TEMPLATE"
+
+// allowFileTemplateDirVar is the environment variable holding the directory the `file` template
+// function may read from. It exists for the same reason as allowEnvTemplateVar: without it, any
+// component metadata value could read anything readable by the sidecar process - another
+// component's mounted secret, a service account token, and so on - just by being configured with
+// the right path. Unset (the default) denies every path.
+const allowFileTemplateDirVar = "DAPR_ALLOW_FILE_TEMPLATE_DIR"
+
+// Template actions that the legacy `{x}` placeholders are rewritten to before rendering; each
+// calls the template function of the same name.
+const (
+	uuidFunc      = "{{ uuid }}"
+	podNameFunc   = "{{ podName }}"
+	namespaceFunc = "{{ namespace }}"
+	appIDFunc     = "{{ appID }}"
+)
+
+// legacyPlaceholders maps the old `strings.Replace`-based placeholder syntax to its equivalent in
+// the new template syntax, so existing component manifests keep working unchanged.
+var legacyPlaceholders = map[string]string{
+	"{uuid}":      uuidFunc,
+	"{podName}":   podNameFunc,
+	"{namespace}": namespaceFunc,
+	"{appID}":     appIDFunc,
+}
+
+// render expands the legacy placeholder syntax into template syntax and then evaluates val as a
+// text/template, with the function set from templateFuncs: uuid, podName, namespace, appID,
+// secret, env, default, file and trim.
+func (m *Meta) render(ctx context.Context, val string) (string, error) {
+	if !strings.Contains(val, "{{") && !hasLegacyPlaceholder(val) {
+		return val, nil
+	}
+
+	for legacy, replacement := range legacyPlaceholders {
+		val = strings.ReplaceAll(val, legacy, replacement)
+	}
+
+	tmpl, err := template.New("metadata").Funcs(m.templateFuncs(ctx)).Parse(val)
+	if err != nil {
+		return "", fmt.Errorf("invalid metadata template: %w", err)
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, nil); err != nil {
+		return "", fmt.Errorf("failed to render metadata template: %w", err)
+	}
+	return out.String(), nil
+}
+
+func hasLegacyPlaceholder(val string) bool {
+	for legacy := range legacyPlaceholders {
+		if strings.Contains(val, legacy) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Meta) templateFuncs(ctx context.Context) template.FuncMap {
+	return template.FuncMap{
+		"uuid": func() string {
+			return uuid.New().String()
+		},
+		"podName": func() (string, error) {
+			if m.podName == "" {
+				return "", fmt.Errorf("metadata refers to podName but podName is not set")
+			}
+			return m.podName, nil
+		},
+		"namespace": func() string {
+			return fmt.Sprintf("%s.%s", m.namespace, m.id)
+		},
+		"appID": func() string {
+			return m.id
+		},
+		"secret": func(storeName, key string) (string, error) {
+			if m.secretGetter == nil {
+				return "", fmt.Errorf("no secret store configured to resolve secret %s/%s", storeName, key)
+			}
+			return m.secretGetter(ctx, storeName, key)
+		},
+		"env": func(name string) (string, error) {
+			if !envTemplateAllowed(name) {
+				return "", fmt.Errorf("reading env var %s in metadata templates is not allowed; add it to %s to allow it", name, allowEnvTemplateVar)
+			}
+			return os.Getenv(name), nil
+		},
+		"file": func(path string) (string, error) {
+			if !fileTemplateAllowed(path) {
+				return "", fmt.Errorf("reading file %s in metadata templates is not allowed; it must be under the directory configured by %s", path, allowFileTemplateDirVar)
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return "", fmt.Errorf("failed to read file %s: %w", path, err)
+			}
+			return string(content), nil
+		},
+		"trim": strings.TrimSpace,
+		"default": func(def, val string) string {
+			if val == "" {
+				return def
+			}
+			return val
+		},
+	}
+}
+
+// envTemplateAllowed reports whether name is present in the comma-separated allowlist configured
+// via the DAPR_ALLOW_ENV_TEMPLATE environment variable.
+func envTemplateAllowed(name string) bool {
+	allowlist := os.Getenv(allowEnvTemplateVar)
+	if allowlist == "" {
+		return false
+	}
+	for _, allowed := range strings.Split(allowlist, ",") {
+		if strings.TrimSpace(allowed) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// fileTemplateAllowed reports whether path resolves to somewhere under the directory configured
+// via DAPR_ALLOW_FILE_TEMPLATE_DIR. An unset or empty allowlist denies every path.
+func fileTemplateAllowed(path string) bool {
+	allowedDir := os.Getenv(allowFileTemplateDirVar)
+	if allowedDir == "" {
+		return false
+	}
+
+	rel, err := filepath.Rel(allowedDir, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2023 The Dapr Authors
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package meta
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testMeta() *Meta {
+	return New(Options{ID: "myapp", PodName: "mypod", Namespace: "default"})
+}
+
+func TestRenderLegacyPlaceholders(t *testing.T) {
+	m := testMeta()
+	ctx := context.Background()
+
+	out, err := m.render(ctx, "{podName}")
+	require.NoError(t, err)
+	assert.Equal(t, "mypod", out)
+
+	out, err = m.render(ctx, "{namespace}")
+	require.NoError(t, err)
+	assert.Equal(t, "default.myapp", out)
+
+	out, err = m.render(ctx, "{appID}")
+	require.NoError(t, err)
+	assert.Equal(t, "myapp", out)
+
+	out, err = m.render(ctx, "{podName}-{appID}")
+	require.NoError(t, err)
+	assert.Equal(t, "mypod-myapp", out)
+
+	first, err := m.render(ctx, "{uuid}")
+	require.NoError(t, err)
+	assert.NotEmpty(t, first)
+	second, err := m.render(ctx, "{uuid}")
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second, "each {uuid} expansion should produce a fresh value")
+}
+
+func TestRenderPodNameUnset(t *testing.T) {
+	m := New(Options{ID: "myapp", Namespace: "default"})
+	_, err := m.render(context.Background(), "{podName}")
+	assert.Error(t, err, "podName template must fail when PodName wasn't configured")
+}
+
+func TestRenderEnvDeniedByDefault(t *testing.T) {
+	m := testMeta()
+	_, err := m.render(context.Background(), `{{ env "FOO" }}`)
+	assert.Error(t, err, "env template function must be denied unless DAPR_ALLOW_ENV_TEMPLATE allows the name")
+}
+
+func TestRenderEnvAllowlist(t *testing.T) {
+	m := testMeta()
+	t.Setenv("FOO", "bar")
+	t.Setenv("BAZ", "qux")
+	t.Setenv(allowEnvTemplateVar, "FOO")
+
+	out, err := m.render(context.Background(), `{{ env "FOO" }}`)
+	require.NoError(t, err)
+	assert.Equal(t, "bar", out)
+
+	_, err = m.render(context.Background(), `{{ env "BAZ" }}`)
+	assert.Error(t, err, "BAZ is not in the allowlist, only FOO is")
+}
+
+func TestRenderFileDeniedByDefault(t *testing.T) {
+	m := testMeta()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "secret.txt")
+	require.NoError(t, os.WriteFile(path, []byte("top secret"), 0o600))
+
+	_, err := m.render(context.Background(), `{{ file "`+path+`" }}`)
+	assert.Error(t, err, "file template function must be denied unless DAPR_ALLOW_FILE_TEMPLATE_DIR allows it")
+}
+
+func TestRenderFileAllowlist(t *testing.T) {
+	m := testMeta()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "secret.txt")
+	require.NoError(t, os.WriteFile(path, []byte("top secret"), 0o600))
+
+	t.Setenv(allowFileTemplateDirVar, dir)
+
+	out, err := m.render(context.Background(), `{{ file "`+path+`" }}`)
+	require.NoError(t, err)
+	assert.Equal(t, "top secret", out)
+
+	outsideDir := t.TempDir()
+	outsidePath := filepath.Join(outsideDir, "other.txt")
+	require.NoError(t, os.WriteFile(outsidePath, []byte("nope"), 0o600))
+
+	_, err = m.render(context.Background(), `{{ file "`+outsidePath+`" }}`)
+	assert.Error(t, err, "a path outside the allowed directory must still be denied")
+}